@@ -2,10 +2,14 @@ package gosqlite
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
 	"slices"
+
+	"github.com/tinyrange/gosqlite/schema"
 )
 
 var endian = binary.BigEndian
@@ -21,7 +25,7 @@ func (r BinaryReader) u8(off int64) uint8         { return uint8(r[off]) }
 func (r BinaryReader) u24(off int64) uint32 {
 	var b [4]byte
 
-	copy(b[:], r[off:off+3])
+	copy(b[1:], r[off:off+3])
 
 	return endian.Uint32(b[:])
 }
@@ -29,7 +33,7 @@ func (r BinaryReader) u24(off int64) uint32 {
 func (r BinaryReader) u48(off int64) uint64 {
 	var b [8]byte
 
-	copy(b[:], r[off:off+6])
+	copy(b[2:], r[off:off+6])
 
 	return endian.Uint64(b[:])
 }
@@ -73,107 +77,188 @@ type Table struct {
 	Name     string
 	rootPage uint64
 	Sql      string
+	Indexes  map[string]*Index
+
+	columns    []schema.Column
+	rowidAlias int // index into columns of the INTEGER PRIMARY KEY alias, or -1
 }
 
-func (t *Table) Read(cb func(val []any) error) error {
-	rowIds := make(map[uint64]bool)
-	return t.db.readPage(int(t.rootPage), func(rowId uint64, payload BinaryReader) error {
-		// HACK: Right now we just ignore reading duplicate rows.
-		if _, ok := rowIds[rowId]; ok {
-			return nil
+// readValues scans the table's B-tree, decoding each distinct row's record
+// once and handing it to cb along with its rowid. It is the shared core of
+// Read and Rows, which differ only in how they present the decoded values.
+func (t *Table) readValues(cb func(rowId uint64, values []any) error) error {
+	for row, err := range t.All() {
+		if err != nil {
+			return err
 		}
 
-		rowIds[rowId] = true
-
-		// Read the cell header length.
-		hdrLen, payloadOff := payload.varint(0)
-		if payloadOff == -1 {
-			return fmt.Errorf("[outer] overflow reading page")
-		}
-		if hdrLen > uint64(len(payload)) {
-			return fmt.Errorf("hdrLen is longer than the payload %d > %d: %+v", hdrLen, len(payload), payload)
+		if err := cb(row.rowID, row.values); err != nil {
+			return err
 		}
+	}
 
-		var (
-			types []uint64
-			typ   uint64
-		)
+	return nil
+}
 
-		for {
-			if payloadOff >= int64(hdrLen) {
-				break
-			}
+func (t *Table) Read(cb func(val []any) error) error {
+	return t.readValues(func(_ uint64, values []any) error {
+		return cb(values)
+	})
+}
 
-			typ, payloadOff = payload.varint(payloadOff)
-			if payloadOff == -1 {
-				return fmt.Errorf("[inner] overflow reading page")
-			}
+// decodeRecord parses a SQLite record (a serial-type header followed by the
+// values it describes) into its positional values. It is shared by table row
+// decoding and index key decoding, since both use the same record format.
+func decodeRecord(payload BinaryReader) ([]any, error) {
+	// Read the cell header length.
+	hdrLen, payloadOff := payload.varint(0)
+	if payloadOff == -1 {
+		return nil, fmt.Errorf("[outer] overflow reading page")
+	}
+	if hdrLen > uint64(len(payload)) {
+		return nil, fmt.Errorf("hdrLen is longer than the payload %d > %d: %+v", hdrLen, len(payload), payload)
+	}
+
+	var (
+		types []uint64
+		typ   uint64
+	)
+
+	for {
+		if payloadOff >= int64(hdrLen) {
+			break
+		}
 
-			types = append(types, typ)
+		typ, payloadOff = payload.varint(payloadOff)
+		if payloadOff == -1 {
+			return nil, fmt.Errorf("[inner] overflow reading page")
 		}
 
-		var values []any
+		types = append(types, typ)
+	}
 
-		for _, typ := range types {
-			if typ == 0 {
-				values = append(values, nil)
-			} else if typ == 1 {
-				values = append(values, uint64(payload.u8(payloadOff)))
+	var values []any
 
-				payloadOff += 1
-			} else if typ == 2 {
-				values = append(values, uint64(payload.u16(payloadOff)))
+	for _, typ := range types {
+		if typ == 0 {
+			values = append(values, nil)
+		} else if typ == 1 {
+			values = append(values, int64(int8(payload.u8(payloadOff))))
 
-				payloadOff += 2
-			} else if typ == 3 {
-				values = append(values, uint64(payload.u24(payloadOff)))
+			payloadOff += 1
+		} else if typ == 2 {
+			values = append(values, int64(int16(payload.u16(payloadOff))))
 
-				payloadOff += 3
-			} else if typ == 4 {
-				values = append(values, uint64(payload.u32(payloadOff)))
+			payloadOff += 2
+		} else if typ == 3 {
+			values = append(values, signExtend(uint64(payload.u24(payloadOff)), 24))
 
-				payloadOff += 4
-			} else if typ == 5 {
-				values = append(values, uint64(payload.u48(payloadOff)))
+			payloadOff += 3
+		} else if typ == 4 {
+			values = append(values, int64(int32(payload.u32(payloadOff))))
 
-				payloadOff += 6
-			} else if typ == 6 {
-				values = append(values, uint64(payload.u64(payloadOff)))
+			payloadOff += 4
+		} else if typ == 5 {
+			values = append(values, signExtend(payload.u48(payloadOff), 48))
 
-				payloadOff += 8
-			} else if typ == 8 {
-				values = append(values, uint64(0))
-			} else if typ == 9 {
-				values = append(values, uint64(1))
-			} else if typ >= 12 && typ%2 == 0 {
-				length := (typ - 12) / 2
+			payloadOff += 6
+		} else if typ == 6 {
+			values = append(values, int64(payload.u64(payloadOff)))
 
-				values = append(values, payload.read(payloadOff, int64(length)))
+			payloadOff += 8
+		} else if typ == 7 {
+			values = append(values, math.Float64frombits(payload.u64(payloadOff)))
 
-				payloadOff += int64(length)
-			} else if typ >= 13 && typ%2 == 1 {
-				length := (typ - 13) / 2
+			payloadOff += 8
+		} else if typ == 8 {
+			values = append(values, int64(0))
+		} else if typ == 9 {
+			values = append(values, int64(1))
+		} else if typ == 10 || typ == 11 {
+			return nil, fmt.Errorf("reserved serial type: %d", typ)
+		} else if typ >= 12 && typ%2 == 0 {
+			length := (typ - 12) / 2
 
-				values = append(values, string(payload.read(payloadOff, int64(length))))
+			values = append(values, payload.read(payloadOff, int64(length)))
 
-				payloadOff += int64(length)
-			} else {
-				return fmt.Errorf("unknown value type: %d", typ)
-			}
-		}
+			payloadOff += int64(length)
+		} else if typ >= 13 && typ%2 == 1 {
+			length := (typ - 13) / 2
 
-		if err := cb(values); err != nil {
-			return err
+			values = append(values, string(payload.read(payloadOff, int64(length))))
+
+			payloadOff += int64(length)
+		} else {
+			return nil, fmt.Errorf("unknown value type: %d", typ)
 		}
+	}
 
-		return nil
-	})
+	return values, nil
+}
+
+// signExtend treats the low bits-wide value v as a two's-complement integer
+// of that width and sign-extends it to a full int64, for the 24- and 48-bit
+// serial types that have no native Go integer type.
+func signExtend(v uint64, bits uint) int64 {
+	shift := 64 - bits
+
+	return int64(v<<shift) >> shift
 }
 
 type SQLiteDatabase struct {
 	r        io.ReaderAt
 	pageSize uint16
+	reserved uint8
 	tables   map[string]*Table
+	pager    *Pager
+	ctx      context.Context // nil means context.Background(); see WithContext
+	walFile  io.Closer        // the "<path>-wal" file opened automatically, if any; see openDatabaseWAL
+}
+
+// Close releases the WAL file OpenDatabase may have opened automatically
+// alongside r (see OpenOptions.WAL). r itself is the caller's and is left
+// open. Close is a no-op if no such WAL file was opened.
+func (db *SQLiteDatabase) Close() error {
+	if db.walFile == nil {
+		return nil
+	}
+
+	return db.walFile.Close()
+}
+
+// context returns the context iterators should check for cancellation,
+// defaulting to context.Background() for a database that wasn't given one.
+func (db *SQLiteDatabase) context() context.Context {
+	if db.ctx != nil {
+		return db.ctx
+	}
+
+	return context.Background()
+}
+
+// WithContext returns a view of db whose table iterators (All, Range) check
+// ctx.Err() between cells and between pages, so a long-running scan can be
+// aborted. The underlying pager and schema are shared with db; only the
+// context used by iteration differs.
+func (db *SQLiteDatabase) WithContext(ctx context.Context) *SQLiteDatabase {
+	clone := *db
+	clone.ctx = ctx
+
+	clone.tables = make(map[string]*Table, len(db.tables))
+	for name, tbl := range db.tables {
+		tblClone := *tbl
+		tblClone.db = &clone
+		clone.tables[name] = &tblClone
+	}
+
+	return &clone
+}
+
+// usableSize returns U, the number of bytes per page actually available for
+// content once the reserved space some extensions store at the end of every
+// page is excluded.
+func (db *SQLiteDatabase) usableSize() int64 {
+	return int64(db.pageSize) - int64(db.reserved)
 }
 
 func (db *SQLiteDatabase) reader(off int64, len int64) (BinaryReader, error) {
@@ -186,12 +271,22 @@ func (db *SQLiteDatabase) reader(off int64, len int64) (BinaryReader, error) {
 	return BinaryReader(data), nil
 }
 
-func (db *SQLiteDatabase) readPage(page int, cbCell func(rowId uint64, r BinaryReader) error) error {
-	var rawPageOffset int64 = (int64(page) - 1) * int64(db.pageSize)
+// pageHeader holds the decoded B-tree page header shared by table and index
+// pages, used by both the recursive table scan in readPage and the targeted
+// index descent in index.go.
+type pageHeader struct {
+	typ          uint8
+	cellPointers []uint16
+	rightMost    uint32 // only set for interior page types (0x02, 0x05)
+}
 
-	pageReader, err := db.reader(rawPageOffset, int64(db.pageSize))
+// readPageHeader fetches page through the database's pager and decodes its
+// B-tree header. The caller must invoke the returned release func once it is
+// done with the returned BinaryReader.
+func (db *SQLiteDatabase) readPageHeader(page int) (BinaryReader, pageHeader, func(), error) {
+	pageReader, release, err := db.pager.Get(uint32(page))
 	if err != nil {
-		return err
+		return nil, pageHeader{}, nil, err
 	}
 
 	var pageOffset int64 = 0
@@ -200,7 +295,9 @@ func (db *SQLiteDatabase) readPage(page int, cbCell func(rowId uint64, r BinaryR
 		pageOffset += 100
 	}
 
-	bTreePageType := pageReader.u8(pageOffset)
+	var hdr pageHeader
+
+	hdr.typ = pageReader.u8(pageOffset)
 	// firstFreeBlock := db.u16(pageOffset + 1)
 	numberOfCells := pageReader.u16(pageOffset + 3)
 	// startOfCellContent := db.u16(pageOffset + 5)
@@ -208,77 +305,110 @@ func (db *SQLiteDatabase) readPage(page int, cbCell func(rowId uint64, r BinaryR
 
 	pageOffset += 8
 
-	var rightMostPointer uint32 = 0
-	if bTreePageType == 0x05 {
-		rightMostPointer = pageReader.u32(pageOffset)
+	if hdr.typ == 0x02 || hdr.typ == 0x05 {
+		hdr.rightMost = pageReader.u32(pageOffset)
 		pageOffset += 4
 	}
 
-	_ = rightMostPointer
+	hdr.cellPointers = make([]uint16, numberOfCells)
+	for i := 0; i < len(hdr.cellPointers); i++ {
+		hdr.cellPointers[i] = pageReader.u16(pageOffset + int64(i)*2)
+	}
 
-	cellPointers := make([]uint16, numberOfCells)
-	for i := 0; i < len(cellPointers); i++ {
-		cellPointers[i] = pageReader.u16(pageOffset + int64(i)*2)
+	return pageReader, hdr, release, nil
+}
+
+// readPage walks every cell reachable from page (descending interior pages
+// as needed) and hands each one to cbCell. It is a thin driver over
+// pageWalker, which does the actual traversal; see iter.go.
+func (db *SQLiteDatabase) readPage(page int, cbCell func(rowId uint64, r BinaryReader) error) error {
+	w, err := newPageWalker(db, page)
+	if err != nil {
+		return err
 	}
+	defer w.close()
 
-	switch bTreePageType {
-	case 0x00: // unknown
-		// ignored since I'm not sure where these come from but it doesn't seem to be a fatal error.
-		return nil
-	case 0x02: // index interior
-		return nil
-	case 0x05: // table interior cell
-		for i, cellPointer := range cellPointers {
-			var off = int64(cellPointer)
-
-			leftMostPointer := pageReader.u32(off)
-			off += 4
-			key, _ := pageReader.varint(off)
-
-			_ = key
-
-			if i == len(cellPointers)-1 {
-				for x := leftMostPointer; x <= rightMostPointer; x++ {
-					if x == uint32(page) {
-						return fmt.Errorf("attempt to re-read own page")
-					}
-
-					if err := db.readPage(int(x), cbCell); err != nil {
-						return err
-					}
-				}
-			} else {
-				if leftMostPointer == uint32(page) {
-					return fmt.Errorf("attempt to re-read own page")
-				}
-
-				if err := db.readPage(int(leftMostPointer), cbCell); err != nil {
-					return err
-				}
-			}
+	for {
+		rowId, payload, ok, err := w.next(context.Background())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
 		}
 
-		return nil
-	case 0x0a: // index leaf
-		return nil
-	case 0x0d: // table exterior cell
-		for _, cellPointer := range cellPointers {
-			var off = int64(cellPointer)
+		if err := cbCell(rowId, payload); err != nil {
+			return err
+		}
+	}
+}
 
-			cellSize, off := pageReader.varint(off)
-			rowId, off := pageReader.varint(off)
+// tableLeafMaxLocal returns X, the largest payload size SQLite stores
+// entirely on a table-leaf page before spilling to overflow pages.
+func tableLeafMaxLocal(u int64) int64 {
+	return u - 35
+}
 
-			payload := BinaryReader(pageReader.read(off, int64(cellSize)))
+// indexMaxLocal returns X for index interior and index leaf pages, which use
+// a different max-local formula than table-leaf pages.
+func indexMaxLocal(u int64) int64 {
+	return ((u - 12) * 64 / 255) - 23
+}
 
-			if err := cbCell(rowId, payload); err != nil {
-				return err
-			}
+// minLocal returns M, the minimum number of payload bytes SQLite keeps
+// locally once a payload has overflowed, regardless of page type.
+func minLocal(u int64) int64 {
+	return ((u - 12) * 32 / 255) - 23
+}
+
+// readOverflowPayload reconstructs a cell's full payload of totalSize bytes
+// starting at off, following SQLite's overflow-page algorithm whenever
+// totalSize exceeds maxLocal (X): the first K bytes (K = M+((P-M)%(U-4)),
+// capped to X) live on the page itself, immediately followed by a 4-byte
+// big-endian pointer to the first overflow page. Each overflow page then
+// contributes usableSize-4 bytes of payload, chained via its own leading
+// 4-byte next-page pointer, until the payload is fully reassembled.
+func (db *SQLiteDatabase) readOverflowPayload(r BinaryReader, off int64, totalSize int64, maxLocal int64) (BinaryReader, error) {
+	if totalSize <= maxLocal {
+		return r.read(off, totalSize), nil
+	}
+
+	u := db.usableSize()
+
+	k := minLocal(u) + ((totalSize - minLocal(u)) % (u - 4))
+	if k > maxLocal {
+		k = maxLocal
+	}
+
+	payload := make([]byte, 0, totalSize)
+	payload = append(payload, r.read(off, k)...)
+
+	overflowPage := r.u32(off + k)
+	remaining := totalSize - k
+
+	for remaining > 0 {
+		if overflowPage == 0 {
+			return nil, fmt.Errorf("truncated overflow chain: %d bytes missing", remaining)
 		}
 
-		return nil
-	default:
-		return fmt.Errorf("unknown bTreePageType: %x", bTreePageType)
+		overflowReader, release, err := db.pager.Get(overflowPage)
+		if err != nil {
+			return nil, err
+		}
+
+		chunk := u - 4
+		if chunk > remaining {
+			chunk = remaining
+		}
+
+		payload = append(payload, overflowReader.read(4, chunk)...)
+		remaining -= chunk
+		overflowPage = overflowReader.u32(0)
+
+		release()
 	}
+
+	return BinaryReader(payload), nil
 }
 
 func (db *SQLiteDatabase) Tables() []string {
@@ -300,7 +430,14 @@ func (db *SQLiteDatabase) Table(name string) (*Table, error) {
 	return tbl, nil
 }
 
-func OpenDatabase(r io.ReaderAt) (*SQLiteDatabase, error) {
+// OpenDatabase opens a SQLite database read through r. opts is optional;
+// passing no OpenOptions selects the defaults.
+func OpenDatabase(r io.ReaderAt, opts ...OpenOptions) (*SQLiteDatabase, error) {
+	var opt OpenOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
 	db := &SQLiteDatabase{r: r, tables: make(map[string]*Table)}
 
 	hdr, err := db.reader(0, 100)
@@ -313,18 +450,66 @@ func OpenDatabase(r io.ReaderAt) (*SQLiteDatabase, error) {
 	}
 
 	db.pageSize = hdr.u16(16)
+	db.reserved = hdr.u8(20)
+
+	cacheBytes := int64(opt.PageCacheBytes)
+	if cacheBytes <= 0 {
+		cacheBytes = defaultPageCacheBytes
+	}
+
+	w, walFile, err := openDatabaseWAL(r, opt)
+	if err != nil {
+		return nil, err
+	}
+	db.walFile = walFile
+
+	db.pager = newPager(r, int64(db.pageSize), cacheBytes, w)
 
 	schemaTable := &Table{db: db, rootPage: 1}
 
+	type indexEntry struct {
+		name, tableName, sql string
+		rootPage             uint64
+	}
+	var indexEntries []indexEntry
+
 	if err := schemaTable.Read(func(val []any) error {
-		if val[0].(string) != "table" {
-			return nil
-		}
+		switch val[0].(string) {
+		case "table":
+			name, _ := val[1].(string)
+
+			tbl := &Table{
+				db:         db,
+				Name:       name,
+				rootPage:   uint64(val[3].(int64)),
+				Sql:        val[4].(string),
+				rowidAlias: -1,
+			}
+
+			if cols, err := schema.Parse(tbl.Sql); err == nil {
+				tbl.columns = cols
+				tbl.rowidAlias = computeRowIDAlias(cols)
+			}
+
+			db.tables[name] = tbl
+		case "index":
+			// Auto-indexes backing UNIQUE/PRIMARY KEY constraints can have a
+			// NULL rootpage and sql when they don't need their own storage
+			// (e.g. a PK on a rowid column); skip those.
+			rootVal, ok := val[3].(int64)
+			if !ok || rootVal == 0 {
+				return nil
+			}
+			root := uint64(rootVal)
+
+			sql, _ := val[4].(string)
 
-		db.tables[val[1].(string)] = &Table{
-			db:       db,
-			rootPage: val[3].(uint64),
-			Sql:      val[4].(string),
+			indexEntries = append(indexEntries, indexEntry{
+				name:      val[1].(string),
+				tableName: val[2].(string),
+				rootPage:  root,
+				sql:       sql,
+			})
 		}
 
 		return nil
@@ -332,9 +517,29 @@ func OpenDatabase(r io.ReaderAt) (*SQLiteDatabase, error) {
 		return nil, err
 	}
 
+	for _, e := range indexEntries {
+		tbl, ok := db.tables[e.tableName]
+		if !ok {
+			continue
+		}
+
+		if tbl.Indexes == nil {
+			tbl.Indexes = make(map[string]*Index)
+		}
+
+		tbl.Indexes[e.name] = &Index{
+			db:        db,
+			Name:      e.name,
+			TableName: e.tableName,
+			rootPage:  e.rootPage,
+			Sql:       e.sql,
+			Columns:   parseIndexColumns(e.sql),
+		}
+	}
+
 	return db, nil
 }
 
-func ParseDatabase(data []byte) (*SQLiteDatabase, error) {
-	return OpenDatabase(bytes.NewReader(data))
+func ParseDatabase(data []byte, opts ...OpenOptions) (*SQLiteDatabase, error) {
+	return OpenDatabase(bytes.NewReader(data), opts...)
 }
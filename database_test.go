@@ -0,0 +1,122 @@
+package gosqlite
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// encodeRecord builds the bytes of a SQLite record (header-length varint,
+// then one serial-type varint per column, then the column values back to
+// back) from columns whose serial types and raw value bytes are supplied
+// directly. Every serial type used by these tests fits in a single varint
+// byte, so the header length itself is encoded the same way.
+func encodeRecord(t *testing.T, types []byte, values ...[]byte) BinaryReader {
+	t.Helper()
+
+	if len(types) != len(values) {
+		t.Fatalf("encodeRecord: %d types but %d value slices", len(types), len(values))
+	}
+
+	hdr := append([]byte{byte(len(types) + 1)}, types...)
+
+	var body []byte
+	for _, v := range values {
+		body = append(body, v...)
+	}
+
+	return BinaryReader(append(hdr, body...))
+}
+
+func be16(v int16) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, uint16(v))
+	return b
+}
+
+func be24(v int32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(v))
+	return b[1:]
+}
+
+func be32(v int32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(v))
+	return b
+}
+
+func be48(v int64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(v))
+	return b[2:]
+}
+
+func be64(v int64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(v))
+	return b
+}
+
+func beFloat64(v float64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, math.Float64bits(v))
+	return b
+}
+
+func TestDecodeRecordSerialTypes(t *testing.T) {
+	payload := encodeRecord(t,
+		[]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 12, 14, 13, 15},
+		nil,                      // 0: NULL
+		[]byte{0xFF},             // 1: int8 -1
+		be16(-300),               // 2: int16 -300
+		be24(-70000),             // 3: int24 -70000
+		be32(-2000000000),        // 4: int32 -2000000000
+		be48(-1_099_511_627_776), // 5: int48
+		be64(math.MinInt64 + 1),  // 6: int64
+		beFloat64(3.14159),       // 7: float64
+		nil,                      // 8: constant 0
+		nil,                      // 9: constant 1
+		nil,                      // 12: zero-length blob
+		[]byte{0xAB},             // 14: 1-byte blob
+		nil,                      // 13: zero-length text
+		[]byte("A"),              // 15: 1-byte text
+	)
+
+	got, err := decodeRecord(payload)
+	if err != nil {
+		t.Fatalf("decodeRecord returned error: %v", err)
+	}
+
+	want := []any{
+		nil,
+		int64(-1),
+		int64(-300),
+		int64(-70000),
+		int64(-2000000000),
+		int64(-1_099_511_627_776),
+		int64(math.MinInt64 + 1),
+		3.14159,
+		int64(0),
+		int64(1),
+		[]byte{},
+		[]byte{0xAB},
+		"",
+		"A",
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("decodeRecord = %#v, want %#v", got, want)
+	}
+}
+
+func TestDecodeRecordReservedSerialTypeIsAnError(t *testing.T) {
+	for _, typ := range []byte{10, 11} {
+		payload := encodeRecord(t, []byte{typ}, nil)
+
+		if _, err := decodeRecord(payload); err == nil {
+			t.Fatalf("decodeRecord with reserved serial type %d: expected an error, got none", typ)
+		}
+	}
+}
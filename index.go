@@ -0,0 +1,520 @@
+package gosqlite
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"iter"
+	"strings"
+)
+
+// Collation identifies one of SQLite's built-in text collating sequences,
+// used when comparing index keys.
+type Collation int
+
+const (
+	CollationBinary Collation = iota
+	CollationNocase
+	CollationRtrim
+)
+
+// IndexColumn describes one column of an index's key, in index order.
+type IndexColumn struct {
+	Name      string
+	Collation Collation
+}
+
+// Index describes a secondary index discovered in sqlite_schema: its root
+// page and the (possibly collated) columns it is keyed on.
+type Index struct {
+	db        *SQLiteDatabase
+	Name      string
+	TableName string
+	rootPage  uint64
+	Sql       string
+	Columns   []IndexColumn
+}
+
+// parseIndexColumns extracts the indexed column names (and any explicit
+// collation) from a `CREATE INDEX ... ON tbl (col, col COLLATE NOCASE, ...)`
+// statement. Like the rest of this package's DDL handling, this is a minimal
+// parse of just the column list, not a general SQL parser.
+func parseIndexColumns(sql string) []IndexColumn {
+	open := strings.IndexByte(sql, '(')
+	closeIdx := strings.LastIndexByte(sql, ')')
+	if open == -1 || closeIdx == -1 || closeIdx <= open {
+		return nil
+	}
+
+	body := sql[open+1 : closeIdx]
+
+	var (
+		cols  []IndexColumn
+		depth int
+		start int
+	)
+
+	for i := 0; i <= len(body); i++ {
+		atEnd := i == len(body)
+
+		var c byte
+		if !atEnd {
+			c = body[i]
+		}
+
+		switch {
+		case !atEnd && c == '(':
+			depth++
+		case !atEnd && c == ')':
+			depth--
+		case atEnd || (c == ',' && depth == 0):
+			cols = append(cols, parseIndexColumn(body[start:i]))
+			start = i + 1
+		}
+	}
+
+	return cols
+}
+
+func parseIndexColumn(field string) IndexColumn {
+	field = strings.TrimSpace(field)
+
+	col := IndexColumn{Collation: CollationBinary}
+
+	fields := strings.Fields(field)
+	if len(fields) == 0 {
+		return col
+	}
+
+	col.Name = strings.Trim(fields[0], `"'`+"`"+`[]`)
+
+	switch upper := strings.ToUpper(field); {
+	case strings.Contains(upper, "COLLATE NOCASE"):
+		col.Collation = CollationNocase
+	case strings.Contains(upper, "COLLATE RTRIM"):
+		col.Collation = CollationRtrim
+	}
+
+	return col
+}
+
+// compareValues compares two decoded record values under the given
+// collation, following SQLite's default ordering: NULL sorts before
+// everything else, numeric types compare numerically, TEXT compares under
+// the given collation, and BLOB compares byte-for-byte.
+func compareValues(a, b any, c Collation) int {
+	if a == nil && b == nil {
+		return 0
+	}
+	if a == nil {
+		return -1
+	}
+	if b == nil {
+		return 1
+	}
+
+	an, aIsNum := asNumber(a)
+	bn, bIsNum := asNumber(b)
+	if aIsNum && bIsNum {
+		switch {
+		case an < bn:
+			return -1
+		case an > bn:
+			return 1
+		default:
+			return 0
+		}
+	}
+	if aIsNum != bIsNum {
+		if aIsNum {
+			return -1
+		}
+		return 1
+	}
+
+	as, aIsStr := a.(string)
+	bs, bIsStr := b.(string)
+	if aIsStr && bIsStr {
+		switch c {
+		case CollationNocase:
+			as, bs = strings.ToUpper(as), strings.ToUpper(bs)
+		case CollationRtrim:
+			as, bs = strings.TrimRight(as, " "), strings.TrimRight(bs, " ")
+		}
+
+		return strings.Compare(as, bs)
+	}
+	if aIsStr != bIsStr {
+		if aIsStr {
+			return -1
+		}
+		return 1
+	}
+
+	ab, aIsBlob := a.([]byte)
+	bb, bIsBlob := b.([]byte)
+	if aIsBlob && bIsBlob {
+		return bytes.Compare(ab, bb)
+	}
+
+	return 0
+}
+
+func asNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+// readIndexLeafPayload reads the record bytes of an index-leaf cell (0x0a):
+// a payload-length varint followed by the record itself, which may spill
+// onto overflow pages exactly like a table-leaf cell does.
+func readIndexLeafPayload(db *SQLiteDatabase, r BinaryReader, cellOffset int64) (BinaryReader, error) {
+	cellSize, off := r.varint(cellOffset)
+	if off == -1 {
+		return nil, fmt.Errorf("overflow reading index leaf cell")
+	}
+
+	return db.readOverflowPayload(r, off, int64(cellSize), indexMaxLocal(db.usableSize()))
+}
+
+// readIndexInteriorCell reads an index-interior cell (0x02): a 4-byte
+// left-child page number followed by a payload-length varint and the key
+// record, which may spill onto overflow pages exactly like a leaf cell's
+// does.
+func readIndexInteriorCell(db *SQLiteDatabase, r BinaryReader, cellOffset int64) (leftChild uint32, payload BinaryReader, err error) {
+	leftChild = r.u32(cellOffset)
+
+	cellSize, off := r.varint(cellOffset + 4)
+	if off == -1 {
+		return 0, nil, fmt.Errorf("overflow reading index interior cell")
+	}
+
+	payload, err = db.readOverflowPayload(r, off, int64(cellSize), indexMaxLocal(db.usableSize()))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return leftChild, payload, nil
+}
+
+// compareKey compares a (possibly partial) search key against a decoded
+// index record, column by column, using each column's collation. The
+// record's trailing element (the rowid reference) is never compared.
+func (idx *Index) compareKey(key []any, record []any) int {
+	for i, k := range key {
+		if i >= len(record) {
+			return 1
+		}
+
+		coll := CollationBinary
+		if i < len(idx.Columns) {
+			coll = idx.Columns[i].Collation
+		}
+
+		if c := compareValues(k, record[i], coll); c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
+
+func (idx *Index) rowIDOf(record []any) uint64 {
+	if len(record) == 0 {
+		return 0
+	}
+
+	v, _ := record[len(record)-1].(int64)
+	return uint64(v)
+}
+
+// find descends the index B-tree comparing key against each interior cell's
+// key record, following the child that could contain a match, and returns
+// the rowid of the first equal leaf entry.
+func (idx *Index) find(key []any) (rowID uint64, found bool, err error) {
+	page := int(idx.rootPage)
+
+	for {
+		pageReader, hdr, release, err := idx.db.readPageHeader(page)
+		if err != nil {
+			return 0, false, err
+		}
+		defer release()
+
+		switch hdr.typ {
+		case 0x0a: // index leaf
+			for _, cellPointer := range hdr.cellPointers {
+				payload, err := readIndexLeafPayload(idx.db, pageReader, int64(cellPointer))
+				if err != nil {
+					return 0, false, err
+				}
+
+				record, err := decodeRecord(payload)
+				if err != nil {
+					return 0, false, err
+				}
+
+				if idx.compareKey(key, record) == 0 {
+					return idx.rowIDOf(record), true, nil
+				}
+			}
+
+			return 0, false, nil
+		case 0x02: // index interior
+			next := hdr.rightMost
+
+			for _, cellPointer := range hdr.cellPointers {
+				leftChild, payload, err := readIndexInteriorCell(idx.db, pageReader, int64(cellPointer))
+				if err != nil {
+					return 0, false, err
+				}
+
+				record, err := decodeRecord(payload)
+				if err != nil {
+					return 0, false, err
+				}
+
+				// An interior cell holds a genuine index entry, not just a
+				// separator copy: a match may live here rather than in
+				// either child subtree.
+				cmp := idx.compareKey(key, record)
+				if cmp == 0 {
+					return idx.rowIDOf(record), true, nil
+				}
+
+				if cmp <= 0 {
+					next = leftChild
+					break
+				}
+			}
+
+			page = int(next)
+		default:
+			return 0, false, fmt.Errorf("unexpected page type in index b-tree: %x", hdr.typ)
+		}
+	}
+}
+
+// scan walks the subtree rooted at page in key order, invoking cb with the
+// rowid of every leaf entry within [low, high] (either bound may be nil for
+// an open range), pruning child subtrees that fall entirely outside it.
+func (idx *Index) scan(page int, low, high []any, cb func(rowID uint64) error) error {
+	pageReader, hdr, release, err := idx.db.readPageHeader(page)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	switch hdr.typ {
+	case 0x0a: // index leaf
+		for _, cellPointer := range hdr.cellPointers {
+			payload, err := readIndexLeafPayload(idx.db, pageReader, int64(cellPointer))
+			if err != nil {
+				return err
+			}
+
+			record, err := decodeRecord(payload)
+			if err != nil {
+				return err
+			}
+
+			if low != nil && idx.compareKey(low, record) > 0 {
+				continue
+			}
+			if high != nil && idx.compareKey(high, record) < 0 {
+				continue
+			}
+
+			if err := cb(idx.rowIDOf(record)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case 0x02: // index interior
+		children := make([]uint32, 0, len(hdr.cellPointers)+1)
+		keys := make([][]any, 0, len(hdr.cellPointers))
+
+		for _, cellPointer := range hdr.cellPointers {
+			leftChild, payload, err := readIndexInteriorCell(idx.db, pageReader, int64(cellPointer))
+			if err != nil {
+				return err
+			}
+
+			record, err := decodeRecord(payload)
+			if err != nil {
+				return err
+			}
+
+			children = append(children, leftChild)
+			keys = append(keys, record)
+		}
+		children = append(children, hdr.rightMost)
+
+		for i, child := range children {
+			if i > 0 && high != nil && idx.compareKey(high, keys[i-1]) < 0 {
+				break
+			}
+			if i < len(keys) && low != nil && idx.compareKey(low, keys[i]) > 0 {
+				continue
+			}
+
+			if err := idx.scan(int(child), low, high, cb); err != nil {
+				return err
+			}
+
+			// Cell i's own key record is a genuine index entry sitting
+			// between the subtree we just descended and the next one, not
+			// merely a separator; emit it too if it falls in range.
+			if i < len(keys) {
+				record := keys[i]
+
+				if low != nil && idx.compareKey(low, record) > 0 {
+					continue
+				}
+				if high != nil && idx.compareKey(high, record) < 0 {
+					continue
+				}
+
+				if err := cb(idx.rowIDOf(record)); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unexpected page type in index b-tree: %x", hdr.typ)
+	}
+}
+
+// tableRowByRowID descends a table B-tree rooted at rootPage looking for the
+// leaf cell with the given rowid, returning its raw record payload.
+func (db *SQLiteDatabase) tableRowByRowID(rootPage uint64, rowID uint64) (BinaryReader, bool, error) {
+	page := int(rootPage)
+
+	for {
+		pageReader, hdr, release, err := db.readPageHeader(page)
+		if err != nil {
+			return nil, false, err
+		}
+		defer release()
+
+		switch hdr.typ {
+		case 0x0d: // table leaf
+			for _, cellPointer := range hdr.cellPointers {
+				off := int64(cellPointer)
+
+				cellSize, off := pageReader.varint(off)
+				cellRowID, off := pageReader.varint(off)
+
+				if cellRowID == rowID {
+					payload, err := db.readOverflowPayload(pageReader, off, int64(cellSize), tableLeafMaxLocal(db.usableSize()))
+					return payload, true, err
+				}
+			}
+
+			return nil, false, nil
+		case 0x05: // table interior
+			next := hdr.rightMost
+
+			for _, cellPointer := range hdr.cellPointers {
+				off := int64(cellPointer)
+
+				leftChild := pageReader.u32(off)
+				off += 4
+
+				key, _ := pageReader.varint(off)
+
+				if rowID <= key {
+					next = leftChild
+					break
+				}
+			}
+
+			page = int(next)
+		default:
+			return nil, false, fmt.Errorf("unexpected page type in table b-tree: %x", hdr.typ)
+		}
+	}
+}
+
+// Lookup finds the row whose indexed column values equal key by descending
+// the named index's B-tree, rather than scanning the whole table.
+func (t *Table) Lookup(indexName string, key ...any) (Row, bool, error) {
+	idx, ok := t.Indexes[indexName]
+	if !ok {
+		return Row{}, false, fmt.Errorf("index not found: %s", indexName)
+	}
+
+	rowID, found, err := idx.find(key)
+	if err != nil || !found {
+		return Row{}, false, err
+	}
+
+	payload, ok, err := t.db.tableRowByRowID(t.rootPage, rowID)
+	if err != nil || !ok {
+		return Row{}, false, err
+	}
+
+	values, err := decodeRecord(payload)
+	if err != nil {
+		return Row{}, false, err
+	}
+
+	return Row{table: t, rowID: rowID, values: values}, true, nil
+}
+
+// Scan walks the named index in key order over [low, high] (either bound may
+// be nil for an open range) and invokes cb with each matching row.
+func (t *Table) Scan(indexName string, low, high []any, cb func(Row) error) error {
+	idx, ok := t.Indexes[indexName]
+	if !ok {
+		return fmt.Errorf("index not found: %s", indexName)
+	}
+
+	return idx.scan(int(idx.rootPage), low, high, func(rowID uint64) error {
+		payload, ok, err := t.db.tableRowByRowID(t.rootPage, rowID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+
+		values, err := decodeRecord(payload)
+		if err != nil {
+			return err
+		}
+
+		return cb(Row{table: t, rowID: rowID, values: values})
+	})
+}
+
+// errStopRange is returned by Scan's callback to unwind it early once
+// ScanRange's caller stops ranging, and is never itself surfaced as an error.
+var errStopRange = errors.New("gosqlite: range stopped")
+
+// ScanRange is Scan in iterator form: range over it with a normal for ...
+// range statement, or break out of the range early to stop the underlying
+// scan without reading further pages. Use (*SQLiteDatabase).WithContext to
+// make it cancellable.
+func (t *Table) ScanRange(indexName string, low, high []any) iter.Seq2[Row, error] {
+	return func(yield func(Row, error) bool) {
+		err := t.Scan(indexName, low, high, func(row Row) error {
+			if !yield(row, nil) {
+				return errStopRange
+			}
+			return nil
+		})
+		if err != nil && err != errStopRange {
+			yield(Row{}, err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package gosqlite
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// openIndexFixture opens testdata/index.db, a real SQLite database (built
+// with Python's sqlite3 module) containing table t(id, val, name) with 5000
+// rows and an index idx_val on val whose root page is a genuine multi-level
+// B-tree interior page, so these tests exercise interior-page entries, not
+// just leaves.
+func openIndexFixture(t *testing.T) *Table {
+	t.Helper()
+
+	f, err := os.Open("testdata/index.db")
+	if err != nil {
+		t.Fatalf("opening fixture: %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+
+	db, err := OpenDatabase(f)
+	if err != nil {
+		t.Fatalf("OpenDatabase: %v", err)
+	}
+
+	tbl, err := db.Table("t")
+	if err != nil {
+		t.Fatalf("Table(t): %v", err)
+	}
+
+	return tbl
+}
+
+func TestIndexLookupFindsEveryRow(t *testing.T) {
+	tbl := openIndexFixture(t)
+
+	const rowCount = 5000
+
+	for i := 0; i < rowCount; i++ {
+		row, found, err := tbl.Lookup("idx_val", int64(i))
+		if err != nil {
+			t.Fatalf("Lookup(%d): %v", i, err)
+		}
+		if !found {
+			t.Fatalf("Lookup(%d): not found (likely an interior-page entry dropped by the index descent)", i)
+		}
+
+		if got := row.Int64(1); got != int64(i) {
+			t.Fatalf("Lookup(%d): row val = %d, want %d", i, got, i)
+		}
+
+		wantName := fmt.Sprintf("row-%d", i)
+		if got, _ := row.Get("name").(string); got != wantName {
+			t.Fatalf("Lookup(%d): row name = %q, want %q", i, got, wantName)
+		}
+	}
+}
+
+func TestIndexLookupMissingKey(t *testing.T) {
+	tbl := openIndexFixture(t)
+
+	_, found, err := tbl.Lookup("idx_val", int64(1_000_000))
+	if err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+	if found {
+		t.Fatal("Lookup found a key that was never inserted")
+	}
+}
+
+func TestIndexScanFullRangeVisitsEveryRowExactlyOnce(t *testing.T) {
+	tbl := openIndexFixture(t)
+
+	const rowCount = 5000
+
+	seen := make(map[int64]bool, rowCount)
+
+	if err := tbl.Scan("idx_val", nil, nil, func(row Row) error {
+		val := row.Int64(1)
+
+		if seen[val] {
+			t.Fatalf("Scan visited val=%d more than once", val)
+		}
+		seen[val] = true
+
+		return nil
+	}); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+
+	if len(seen) != rowCount {
+		t.Fatalf("Scan visited %d distinct rows, want %d", len(seen), rowCount)
+	}
+}
+
+func TestIndexScanRangeMatchesScan(t *testing.T) {
+	tbl := openIndexFixture(t)
+
+	low, high := int64(1000), int64(1010)
+
+	var got []int64
+	for row, err := range tbl.ScanRange("idx_val", []any{low}, []any{high}) {
+		if err != nil {
+			t.Fatalf("ScanRange: %v", err)
+		}
+		got = append(got, row.Int64(1))
+	}
+
+	if len(got) != int(high-low+1) {
+		t.Fatalf("ScanRange(%d, %d) visited %d rows, want %d", low, high, len(got), high-low+1)
+	}
+}
+
+func TestIndexScanRangeStopsEarly(t *testing.T) {
+	tbl := openIndexFixture(t)
+
+	var got []int64
+	for row, err := range tbl.ScanRange("idx_val", nil, nil) {
+		if err != nil {
+			t.Fatalf("ScanRange: %v", err)
+		}
+
+		got = append(got, row.Int64(1))
+		if len(got) == 3 {
+			break
+		}
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("ScanRange visited %d rows before stopping, want 3", len(got))
+	}
+}
+
+func TestIndexScanBoundedRange(t *testing.T) {
+	tbl := openIndexFixture(t)
+
+	low, high := int64(1000), int64(1010)
+
+	var got []int64
+	if err := tbl.Scan("idx_val", []any{low}, []any{high}, func(row Row) error {
+		got = append(got, row.Int64(1))
+		return nil
+	}); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+
+	if len(got) != int(high-low+1) {
+		t.Fatalf("Scan(%d, %d) visited %d rows, want %d", low, high, len(got), high-low+1)
+	}
+
+	seen := make(map[int64]bool, len(got))
+	for _, v := range got {
+		if v < low || v > high {
+			t.Fatalf("Scan(%d, %d) visited out-of-range val=%d", low, high, v)
+		}
+		seen[v] = true
+	}
+
+	if len(seen) != len(got) {
+		t.Fatalf("Scan(%d, %d) visited a row more than once", low, high)
+	}
+}
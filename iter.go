@@ -0,0 +1,244 @@
+package gosqlite
+
+import (
+	"context"
+	"fmt"
+	"iter"
+)
+
+// pageFrame is one level of an explicit traversal stack: a page together
+// with how far pageWalker has gotten through its cells.
+type pageFrame struct {
+	page       int
+	pageReader BinaryReader
+	hdr        pageHeader
+	release    func()
+	cellIndex  int
+
+	// pushedRightmost tracks whether the interior page's trailing
+	// right-most child (the one subtree with no separator cell of its
+	// own) has been pushed yet. An interior page with N cells has exactly
+	// N+1 children: each cell's own left child, plus this one.
+	pushedRightmost bool
+}
+
+// pageWalker walks a B-tree (table or index) as an explicit stack of
+// (page, cellIndex) frames instead of recursion, so a pull-style iterator
+// can suspend between cells and check for cancellation without needing a
+// goroutine. next yields every leaf cell reachable from the root, in B-tree
+// order, descending interior pages as needed; it is the shared engine
+// behind readPage and the Table.All/Range iterators.
+type pageWalker struct {
+	db    *SQLiteDatabase
+	stack []pageFrame
+}
+
+func newPageWalker(db *SQLiteDatabase, rootPage int) (*pageWalker, error) {
+	w := &pageWalker{db: db}
+
+	if err := w.push(rootPage); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *pageWalker) push(page int) error {
+	pageReader, hdr, release, err := w.db.readPageHeader(page)
+	if err != nil {
+		return err
+	}
+
+	w.stack = append(w.stack, pageFrame{page: page, pageReader: pageReader, hdr: hdr, release: release})
+
+	return nil
+}
+
+// close releases every page still held by the walker's stack. Safe to call
+// on a walker that hasn't been run to completion.
+func (w *pageWalker) close() {
+	for _, f := range w.stack {
+		f.release()
+	}
+
+	w.stack = nil
+}
+
+// next advances to the next leaf cell, returning its rowid (0 for index
+// leaves, whose payload carries the rowid as its final record column) and
+// payload. ok is false once the whole subtree has been exhausted. ctx is
+// checked once per frame visited, i.e. between cells and between pages.
+func (w *pageWalker) next(ctx context.Context) (rowId uint64, payload BinaryReader, ok bool, err error) {
+	for len(w.stack) > 0 {
+		if err := ctx.Err(); err != nil {
+			return 0, nil, false, err
+		}
+
+		top := &w.stack[len(w.stack)-1]
+
+		switch top.hdr.typ {
+		case 0x00: // unknown
+			top.release()
+			w.stack = w.stack[:len(w.stack)-1]
+
+		case 0x02, 0x05: // index / table interior
+			if top.cellIndex < len(top.hdr.cellPointers) {
+				cellPointer := top.hdr.cellPointers[top.cellIndex]
+				top.cellIndex++
+
+				var leftChild uint32
+				if top.hdr.typ == 0x02 {
+					leftChild, _, err = readIndexInteriorCell(w.db, top.pageReader, int64(cellPointer))
+					if err != nil {
+						return 0, nil, false, err
+					}
+				} else {
+					leftChild = top.pageReader.u32(int64(cellPointer))
+				}
+
+				if leftChild == uint32(top.page) {
+					return 0, nil, false, fmt.Errorf("attempt to re-read own page")
+				}
+
+				if err := w.push(int(leftChild)); err != nil {
+					return 0, nil, false, err
+				}
+
+				continue
+			}
+
+			if !top.pushedRightmost {
+				top.pushedRightmost = true
+
+				if top.hdr.rightMost == uint32(top.page) {
+					return 0, nil, false, fmt.Errorf("attempt to re-read own page")
+				}
+
+				if err := w.push(int(top.hdr.rightMost)); err != nil {
+					return 0, nil, false, err
+				}
+
+				continue
+			}
+
+			top.release()
+			w.stack = w.stack[:len(w.stack)-1]
+
+		case 0x0a: // index leaf
+			if top.cellIndex >= len(top.hdr.cellPointers) {
+				top.release()
+				w.stack = w.stack[:len(w.stack)-1]
+
+				continue
+			}
+
+			cellPointer := top.hdr.cellPointers[top.cellIndex]
+			top.cellIndex++
+
+			payload, err = readIndexLeafPayload(w.db, top.pageReader, int64(cellPointer))
+			if err != nil {
+				return 0, nil, false, err
+			}
+
+			return 0, payload, true, nil
+
+		case 0x0d: // table leaf
+			if top.cellIndex >= len(top.hdr.cellPointers) {
+				top.release()
+				w.stack = w.stack[:len(w.stack)-1]
+
+				continue
+			}
+
+			cellPointer := top.hdr.cellPointers[top.cellIndex]
+			top.cellIndex++
+
+			off := int64(cellPointer)
+			cellSize, off := top.pageReader.varint(off)
+			rowId, off = top.pageReader.varint(off)
+
+			payload, err = w.db.readOverflowPayload(top.pageReader, off, int64(cellSize), tableLeafMaxLocal(w.db.usableSize()))
+			if err != nil {
+				return 0, nil, false, err
+			}
+
+			return rowId, payload, true, nil
+
+		default:
+			return 0, nil, false, fmt.Errorf("unknown bTreePageType: %x", top.hdr.typ)
+		}
+	}
+
+	return 0, nil, false, nil
+}
+
+// RowID is a table row's rowid, as used by Table.Range's bounds.
+type RowID int64
+
+// rows returns an iterator over the table's distinct rows, in rowid order,
+// restricted to those for which filter returns true (filter == nil means
+// every row). It is the shared core of All and Range.
+func (t *Table) rows(filter func(rowId uint64) bool) iter.Seq2[Row, error] {
+	return func(yield func(Row, error) bool) {
+		ctx := t.db.context()
+
+		w, err := newPageWalker(t.db, int(t.rootPage))
+		if err != nil {
+			yield(Row{}, err)
+			return
+		}
+		defer w.close()
+
+		rowIds := make(map[uint64]bool)
+
+		for {
+			rowId, payload, ok, err := w.next(ctx)
+			if err != nil {
+				yield(Row{}, err)
+				return
+			}
+			if !ok {
+				return
+			}
+
+			// HACK: Right now we just ignore reading duplicate rows.
+			if rowIds[rowId] {
+				continue
+			}
+			rowIds[rowId] = true
+
+			if filter != nil && !filter(rowId) {
+				continue
+			}
+
+			values, err := decodeRecord(payload)
+			if err != nil {
+				if !yield(Row{}, err) {
+					return
+				}
+
+				continue
+			}
+
+			if !yield(Row{table: t, rowID: rowId, values: values}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// All returns a pull-style iterator over every distinct row of the table,
+// in B-tree order. Range over it with a normal for ... range statement, or
+// break out of the range early to stop the underlying scan without reading
+// further pages. Use (*SQLiteDatabase).WithContext to make it cancellable.
+func (t *Table) All() iter.Seq2[Row, error] {
+	return t.rows(nil)
+}
+
+// Range is like All, but restricted to rows whose rowid falls within
+// [low, high].
+func (t *Table) Range(low, high RowID) iter.Seq2[Row, error] {
+	return t.rows(func(rowId uint64) bool {
+		return RowID(rowId) >= low && RowID(rowId) <= high
+	})
+}
@@ -0,0 +1,130 @@
+// Package lru implements a small namespaced least-recently-used cache,
+// modeled on the block cache goleveldb keeps between its backing store and
+// callers.
+package lru
+
+import "container/list"
+
+// Cache is a fixed-capacity, namespaced LRU cache: entries are keyed within
+// a namespace so a single Cache can back several unrelated logical caches
+// without their keys colliding, evicting the least-recently-used unpinned
+// entry once the configured byte budget is exceeded. A pinned entry (one
+// with a positive pin count) is never evicted until it is fully unpinned.
+type Cache[K comparable, V any] struct {
+	capacity int64
+	size     int64
+	sizeOf   func(V) int64
+
+	ll    *list.List
+	items map[cacheKey[K]]*list.Element
+}
+
+type cacheKey[K comparable] struct {
+	ns  uint64
+	key K
+}
+
+type cacheEntry[K comparable, V any] struct {
+	key   cacheKey[K]
+	value V
+	size  int64
+	pins  int
+}
+
+// New creates a Cache bounded to capacity bytes, as measured by sizeOf.
+func New[K comparable, V any](capacity int64, sizeOf func(V) int64) *Cache[K, V] {
+	return &Cache[K, V]{
+		capacity: capacity,
+		sizeOf:   sizeOf,
+		ll:       list.New(),
+		items:    make(map[cacheKey[K]]*list.Element),
+	}
+}
+
+// GetNamespace returns a view over the cache scoped to ns, so unrelated
+// callers sharing one Cache can't collide on keys.
+func (c *Cache[K, V]) GetNamespace(ns uint64) *Namespace[K, V] {
+	return &Namespace[K, V]{cache: c, ns: ns}
+}
+
+func (c *Cache[K, V]) get(k cacheKey[K]) (V, bool) {
+	if el, ok := c.items[k]; ok {
+		c.ll.MoveToFront(el)
+		return el.Value.(*cacheEntry[K, V]).value, true
+	}
+
+	var zero V
+	return zero, false
+}
+
+func (c *Cache[K, V]) put(k cacheKey[K], value V) {
+	size := c.sizeOf(value)
+
+	if el, ok := c.items[k]; ok {
+		c.ll.MoveToFront(el)
+
+		e := el.Value.(*cacheEntry[K, V])
+		c.size += size - e.size
+		e.value = value
+		e.size = size
+	} else {
+		e := &cacheEntry[K, V]{key: k, value: value, size: size}
+		c.items[k] = c.ll.PushFront(e)
+		c.size += size
+	}
+
+	c.evict()
+}
+
+func (c *Cache[K, V]) pin(k cacheKey[K]) {
+	if el, ok := c.items[k]; ok {
+		el.Value.(*cacheEntry[K, V]).pins++
+	}
+}
+
+func (c *Cache[K, V]) unpin(k cacheKey[K]) {
+	if el, ok := c.items[k]; ok {
+		if e := el.Value.(*cacheEntry[K, V]); e.pins > 0 {
+			e.pins--
+		}
+	}
+}
+
+func (c *Cache[K, V]) evict() {
+	for c.size > c.capacity {
+		el := c.ll.Back()
+		for el != nil && el.Value.(*cacheEntry[K, V]).pins > 0 {
+			el = el.Prev()
+		}
+		if el == nil {
+			return
+		}
+
+		e := el.Value.(*cacheEntry[K, V])
+		c.ll.Remove(el)
+		delete(c.items, e.key)
+		c.size -= e.size
+	}
+}
+
+// Namespace is a namespaced view over a shared Cache.
+type Namespace[K comparable, V any] struct {
+	cache *Cache[K, V]
+	ns    uint64
+}
+
+func (n *Namespace[K, V]) Get(key K) (V, bool) {
+	return n.cache.get(cacheKey[K]{ns: n.ns, key: key})
+}
+
+func (n *Namespace[K, V]) Put(key K, value V) {
+	n.cache.put(cacheKey[K]{ns: n.ns, key: key}, value)
+}
+
+func (n *Namespace[K, V]) Pin(key K) {
+	n.cache.pin(cacheKey[K]{ns: n.ns, key: key})
+}
+
+func (n *Namespace[K, V]) Unpin(key K) {
+	n.cache.unpin(cacheKey[K]{ns: n.ns, key: key})
+}
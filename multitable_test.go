@@ -0,0 +1,63 @@
+package gosqlite
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// openMultiTableFixture opens testdata/multitable.db, a real SQLite database
+// (built with Python's sqlite3 module, page_size=1024) containing two
+// independent tables, a and b, each with 1500 rows, whose root pages are
+// genuine multi-level interior pages. It exercises pageWalker's interior-page
+// descent across more than one B-tree sharing the same page space, where a
+// child-range bug in one table's traversal can silently read pages that
+// actually belong to the other table.
+func openMultiTableFixture(t *testing.T) *SQLiteDatabase {
+	t.Helper()
+
+	f, err := os.Open("testdata/multitable.db")
+	if err != nil {
+		t.Fatalf("opening fixture: %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+
+	db, err := OpenDatabase(f)
+	if err != nil {
+		t.Fatalf("OpenDatabase: %v", err)
+	}
+
+	return db
+}
+
+func TestPageWalkerDoesNotCrossTableBoundaries(t *testing.T) {
+	db := openMultiTableFixture(t)
+
+	for _, name := range []string{"a", "b"} {
+		tbl, err := db.Table(name)
+		if err != nil {
+			t.Fatalf("Table(%s): %v", name, err)
+		}
+
+		seen := make(map[int64]bool)
+		wantPrefix := fmt.Sprintf("%s-row-", name)
+
+		if err := tbl.readValues(func(rowId uint64, values []any) error {
+			val, _ := values[1].(string)
+			if len(val) < len(wantPrefix) || val[:len(wantPrefix)] != wantPrefix {
+				t.Fatalf("table %s: row %d has value %q, want prefix %q (cross-table corruption)", name, rowId, val, wantPrefix)
+			}
+			if seen[int64(rowId)] {
+				t.Fatalf("table %s: rowid %d visited more than once", name, rowId)
+			}
+			seen[int64(rowId)] = true
+			return nil
+		}); err != nil {
+			t.Fatalf("table %s: readValues: %v", name, err)
+		}
+
+		if len(seen) != 1500 {
+			t.Fatalf("table %s: visited %d rows, want 1500", name, len(seen))
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package gosqlite
+
+import (
+	"io"
+	"sync/atomic"
+
+	"github.com/tinyrange/gosqlite/lru"
+)
+
+// defaultPageCacheBytes bounds how much page data the pager keeps resident
+// when OpenOptions.PageCacheBytes isn't set.
+const defaultPageCacheBytes = 1 << 20 // 1 MiB
+
+// Pager sits between a database's underlying io.ReaderAt and readPage,
+// caching recently used pages so that repeated interior-page traversals
+// (e.g. descending the same B-tree over and over during index lookups)
+// don't re-read and re-allocate the same bytes from disk.
+type Pager struct {
+	r        io.ReaderAt
+	pageSize int64
+	cache    *lru.Namespace[uint32, []byte]
+	wal      *wal // non-nil once OpenDatabase finds a WAL to read pages from
+
+	hits, misses uint64
+}
+
+func newPager(r io.ReaderAt, pageSize int64, cacheBytes int64, w *wal) *Pager {
+	c := lru.New[uint32, []byte](cacheBytes, func(v []byte) int64 { return int64(len(v)) })
+
+	return &Pager{r: r, pageSize: pageSize, cache: c.GetNamespace(0), wal: w}
+}
+
+// Get returns the raw bytes of the given page, from cache if resident or
+// freshly read otherwise. A page with a committed WAL frame is served from
+// the WAL instead of the main database file, so readers see the database's
+// current state even when it's open in WAL journal mode. The caller must
+// invoke the returned release func once it is done with the page so it
+// becomes eligible for eviction again.
+func (p *Pager) Get(page uint32) (BinaryReader, func(), error) {
+	if data, ok := p.cache.Get(page); ok {
+		atomic.AddUint64(&p.hits, 1)
+
+		p.cache.Pin(page)
+
+		return BinaryReader(data), func() { p.cache.Unpin(page) }, nil
+	}
+
+	atomic.AddUint64(&p.misses, 1)
+
+	data, err := p.readPage(page)
+	if err != nil {
+		return nil, func() {}, err
+	}
+
+	p.cache.Put(page, data)
+	p.cache.Pin(page)
+
+	return BinaryReader(data), func() { p.cache.Unpin(page) }, nil
+}
+
+// readPage fetches page's raw bytes, preferring the WAL's committed image
+// over the main database file.
+func (p *Pager) readPage(page uint32) ([]byte, error) {
+	if p.wal != nil {
+		if data, ok, err := p.wal.readPage(page); err != nil {
+			return nil, err
+		} else if ok {
+			return []byte(data), nil
+		}
+	}
+
+	data := make([]byte, p.pageSize)
+	if _, err := p.r.ReadAt(data, (int64(page)-1)*p.pageSize); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// OpenOptions configures how OpenDatabase sets up a database.
+type OpenOptions struct {
+	// PageCacheBytes bounds how many bytes of decoded page data the pager
+	// keeps resident. Zero selects defaultPageCacheBytes.
+	PageCacheBytes int
+
+	// WAL, if set, is read as a SQLite WAL file whose committed frames take
+	// precedence over the main database file's pages. If unset and r was
+	// opened from a path (an *os.File), OpenDatabase looks for an adjacent
+	// "<path>-wal" file and uses it the same way if found.
+	WAL io.ReaderAt
+}
+
+// Stats reports page-cache observability counters for a database.
+type Stats struct {
+	PageCacheHits   uint64
+	PageCacheMisses uint64
+}
+
+// Stats returns the database's current page-cache hit/miss counters.
+func (db *SQLiteDatabase) Stats() Stats {
+	return Stats{
+		PageCacheHits:   atomic.LoadUint64(&db.pager.hits),
+		PageCacheMisses: atomic.LoadUint64(&db.pager.misses),
+	}
+}
@@ -0,0 +1,129 @@
+package gosqlite
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/tinyrange/gosqlite/schema"
+)
+
+// Row is a decoded table row, with its values coerced according to the
+// affinity of the column they came from and named access resolved against
+// the table's CREATE TABLE schema.
+type Row struct {
+	table  *Table
+	rowID  uint64
+	values []any
+}
+
+// RowID returns the row's rowid, regardless of whether it is also exposed
+// under a declared INTEGER PRIMARY KEY column.
+func (r Row) RowID() int64 {
+	return int64(r.rowID)
+}
+
+// Get returns the value of the named column, or nil if no such column
+// exists.
+func (r Row) Get(name string) any {
+	for i, c := range r.table.columns {
+		if strings.EqualFold(c.Name, name) {
+			return r.valueAt(i)
+		}
+	}
+
+	return nil
+}
+
+// Int64 returns the i'th column's value as an int64, or 0 if it isn't a
+// number.
+func (r Row) Int64(i int) int64 {
+	v, _ := r.valueAt(i).(int64)
+	return v
+}
+
+func (r Row) valueAt(i int) any {
+	// A column declared INTEGER PRIMARY KEY is an alias for the rowid:
+	// SQLite stores NULL for it and expects readers to substitute the
+	// rowid itself.
+	if i == r.table.rowidAlias {
+		return int64(r.rowID)
+	}
+
+	if i < 0 || i >= len(r.values) {
+		return nil
+	}
+
+	return coerce(r.values[i], r.table.columns[i].Affinity)
+}
+
+// coerce applies SQLite's type-affinity rules to a decoded value: TEXT
+// stored in a column with INTEGER/NUMERIC/REAL affinity is converted to a
+// number when it parses cleanly as one. Values that already match the
+// affinity, or that don't parse, are returned unchanged.
+func coerce(value any, affinity schema.Affinity) any {
+	s, ok := value.(string)
+	if !ok {
+		if affinity == schema.AffinityReal {
+			if v, ok := value.(int64); ok {
+				return float64(v)
+			}
+		}
+
+		return value
+	}
+
+	switch affinity {
+	case schema.AffinityInteger, schema.AffinityNumeric:
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	case schema.AffinityReal:
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+
+	return value
+}
+
+// computeRowIDAlias returns the index of the column that is an alias for
+// the rowid (a single INTEGER column that is the whole primary key), or -1
+// if the table has no such column.
+func computeRowIDAlias(columns []schema.Column) int {
+	alias := -1
+	pkColumns := 0
+
+	for i, c := range columns {
+		if c.PKOrder == 0 {
+			continue
+		}
+
+		pkColumns++
+		if strings.EqualFold(c.DeclaredType, "INTEGER") {
+			alias = i
+		}
+	}
+
+	if pkColumns != 1 {
+		return -1
+	}
+
+	return alias
+}
+
+// Columns returns the table's column schema, parsed from its CREATE TABLE
+// statement.
+func (t *Table) Columns() []schema.Column {
+	return t.columns
+}
+
+// Rows is like Read, but yields a Row with named column access and
+// affinity-coerced values instead of a raw positional slice.
+func (t *Table) Rows(cb func(Row) error) error {
+	return t.readValues(func(rowID uint64, values []any) error {
+		return cb(Row{table: t, rowID: rowID, values: values})
+	})
+}
@@ -0,0 +1,228 @@
+// Package schema does minimal parsing of SQLite's CREATE TABLE DDL: just
+// enough to recover each column's name, declared type, NOT NULL and primary
+// key constraints, and default expression, and to derive its type affinity.
+// It is not a general SQL parser.
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Affinity is one of SQLite's five type affinities, derived from a column's
+// declared type per the rules at https://www.sqlite.org/datatype3.html#affname.
+type Affinity int
+
+const (
+	AffinityBlob Affinity = iota
+	AffinityText
+	AffinityNumeric
+	AffinityInteger
+	AffinityReal
+)
+
+func (a Affinity) String() string {
+	switch a {
+	case AffinityText:
+		return "TEXT"
+	case AffinityNumeric:
+		return "NUMERIC"
+	case AffinityInteger:
+		return "INTEGER"
+	case AffinityReal:
+		return "REAL"
+	default:
+		return "BLOB"
+	}
+}
+
+// Column describes one column of a CREATE TABLE statement.
+type Column struct {
+	Name         string
+	DeclaredType string
+	NotNull      bool
+	PKOrder      int // 1-based position within the primary key, 0 if not part of it
+	Default      string
+	Affinity     Affinity
+}
+
+// affinityOf derives a column's type affinity from its declared type,
+// applying SQLite's rules in order: a declared type is never tested against
+// more than one rule.
+func affinityOf(declaredType string) Affinity {
+	t := strings.ToUpper(declaredType)
+
+	switch {
+	case strings.Contains(t, "INT"):
+		return AffinityInteger
+	case strings.Contains(t, "CHAR"), strings.Contains(t, "CLOB"), strings.Contains(t, "TEXT"):
+		return AffinityText
+	case strings.Contains(t, "BLOB"), t == "":
+		return AffinityBlob
+	case strings.Contains(t, "REAL"), strings.Contains(t, "FLOA"), strings.Contains(t, "DOUB"):
+		return AffinityReal
+	default:
+		return AffinityNumeric
+	}
+}
+
+// Parse extracts the column definitions from a CREATE TABLE statement.
+func Parse(sql string) ([]Column, error) {
+	open := strings.IndexByte(sql, '(')
+	closeIdx := strings.LastIndexByte(sql, ')')
+	if open == -1 || closeIdx == -1 || closeIdx <= open {
+		return nil, fmt.Errorf("schema: no column list found in %q", sql)
+	}
+
+	var (
+		columns   []Column
+		pkCounter int
+		tablePK   []string
+	)
+
+	for _, field := range splitTopLevel(sql[open+1 : closeIdx]) {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		tokens := strings.Fields(field)
+		if len(tokens) >= 2 && strings.EqualFold(tokens[0], "PRIMARY") && strings.EqualFold(tokens[1], "KEY") {
+			tablePK = parseColumnNameList(field)
+			continue
+		}
+
+		col, ok := parseColumn(field, &pkCounter)
+		if !ok {
+			continue
+		}
+
+		columns = append(columns, col)
+	}
+
+	for i, name := range tablePK {
+		for j := range columns {
+			if strings.EqualFold(columns[j].Name, name) {
+				columns[j].PKOrder = i + 1
+			}
+		}
+	}
+
+	return columns, nil
+}
+
+// tableConstraintKeywords are the keywords that introduce a table-level
+// constraint rather than a column definition.
+var tableConstraintKeywords = map[string]bool{
+	"PRIMARY": true, "UNIQUE": true, "CHECK": true, "FOREIGN": true, "CONSTRAINT": true,
+}
+
+// columnConstraintKeywords are the keywords that end a column's declared
+// type and begin its constraint clauses.
+var columnConstraintKeywords = map[string]bool{
+	"PRIMARY": true, "NOT": true, "UNIQUE": true, "CHECK": true,
+	"COLLATE": true, "REFERENCES": true, "GENERATED": true, "DEFAULT": true, "CONSTRAINT": true,
+}
+
+func parseColumn(def string, pkCounter *int) (Column, bool) {
+	tokens := strings.Fields(def)
+	if len(tokens) == 0 {
+		return Column{}, false
+	}
+
+	if tableConstraintKeywords[strings.ToUpper(tokens[0])] {
+		return Column{}, false
+	}
+
+	col := Column{Name: unquote(tokens[0])}
+
+	i := 1
+	for ; i < len(tokens); i++ {
+		if columnConstraintKeywords[strings.ToUpper(tokens[i])] {
+			break
+		}
+	}
+	col.DeclaredType = strings.Join(tokens[1:i], " ")
+	col.Affinity = affinityOf(col.DeclaredType)
+
+	for ; i < len(tokens); i++ {
+		switch strings.ToUpper(tokens[i]) {
+		case "NOT":
+			if i+1 < len(tokens) && strings.EqualFold(tokens[i+1], "NULL") {
+				col.NotNull = true
+				i++
+			}
+		case "PRIMARY":
+			if i+1 < len(tokens) && strings.EqualFold(tokens[i+1], "KEY") {
+				*pkCounter++
+				col.PKOrder = *pkCounter
+				i++
+			}
+		case "DEFAULT":
+			if i+1 < len(tokens) {
+				col.Default = tokens[i+1]
+				i++
+			}
+		}
+	}
+
+	return col, true
+}
+
+// parseColumnNameList extracts the column names from a table-level
+// `PRIMARY KEY (a, b, ...)` constraint.
+func parseColumnNameList(field string) []string {
+	open := strings.IndexByte(field, '(')
+	closeIdx := strings.LastIndexByte(field, ')')
+	if open == -1 || closeIdx == -1 || closeIdx <= open {
+		return nil
+	}
+
+	var names []string
+	for _, part := range splitTopLevel(field[open+1 : closeIdx]) {
+		fields := strings.Fields(strings.TrimSpace(part))
+		if len(fields) == 0 {
+			continue
+		}
+
+		names = append(names, unquote(fields[0]))
+	}
+
+	return names
+}
+
+// splitTopLevel splits body on commas that are not nested inside
+// parentheses, so that e.g. a `VARCHAR(10)` type or a `CHECK(a > b)`
+// constraint isn't split in the middle.
+func splitTopLevel(body string) []string {
+	var (
+		fields []string
+		depth  int
+		start  int
+	)
+
+	for i := 0; i <= len(body); i++ {
+		atEnd := i == len(body)
+
+		var c byte
+		if !atEnd {
+			c = body[i]
+		}
+
+		switch {
+		case !atEnd && c == '(':
+			depth++
+		case !atEnd && c == ')':
+			depth--
+		case atEnd || (c == ',' && depth == 0):
+			fields = append(fields, body[start:i])
+			start = i + 1
+		}
+	}
+
+	return fields
+}
+
+func unquote(s string) string {
+	return strings.Trim(s, `"'`+"`"+`[]`)
+}
@@ -0,0 +1,101 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []Column
+	}{
+		{
+			name: "basic columns and affinities",
+			sql:  `CREATE TABLE t (id INTEGER, name TEXT, price REAL, data BLOB, note VARCHAR(32))`,
+			want: []Column{
+				{Name: "id", DeclaredType: "INTEGER", Affinity: AffinityInteger},
+				{Name: "name", DeclaredType: "TEXT", Affinity: AffinityText},
+				{Name: "price", DeclaredType: "REAL", Affinity: AffinityReal},
+				{Name: "data", DeclaredType: "BLOB", Affinity: AffinityBlob},
+				{Name: "note", DeclaredType: "VARCHAR(32)", Affinity: AffinityText},
+			},
+		},
+		{
+			name: "no declared type gets BLOB affinity",
+			sql:  `CREATE TABLE t (x)`,
+			want: []Column{
+				{Name: "x", DeclaredType: "", Affinity: AffinityBlob},
+			},
+		},
+		{
+			name: "unrecognized declared type gets NUMERIC affinity",
+			sql:  `CREATE TABLE t (x DECIMAL(5,2))`,
+			want: []Column{
+				{Name: "x", DeclaredType: "DECIMAL(5,2)", Affinity: AffinityNumeric},
+			},
+		},
+		{
+			name: "inline column-level primary key",
+			sql:  `CREATE TABLE t (id INTEGER PRIMARY KEY, val TEXT)`,
+			want: []Column{
+				{Name: "id", DeclaredType: "INTEGER", Affinity: AffinityInteger, PKOrder: 1},
+				{Name: "val", DeclaredType: "TEXT", Affinity: AffinityText},
+			},
+		},
+		{
+			name: "table-level composite primary key",
+			sql:  `CREATE TABLE t (a INTEGER, b INTEGER, c TEXT, PRIMARY KEY (b, a))`,
+			want: []Column{
+				{Name: "a", DeclaredType: "INTEGER", Affinity: AffinityInteger, PKOrder: 2},
+				{Name: "b", DeclaredType: "INTEGER", Affinity: AffinityInteger, PKOrder: 1},
+				{Name: "c", DeclaredType: "TEXT", Affinity: AffinityText},
+			},
+		},
+		{
+			name: "not null and default",
+			sql:  `CREATE TABLE t (id INTEGER, qty INTEGER NOT NULL DEFAULT 0)`,
+			want: []Column{
+				{Name: "id", DeclaredType: "INTEGER", Affinity: AffinityInteger},
+				{Name: "qty", DeclaredType: "INTEGER", Affinity: AffinityInteger, NotNull: true, Default: "0"},
+			},
+		},
+		{
+			name: "quoted column names",
+			sql:  "CREATE TABLE t (\"id\" INTEGER, [name] TEXT, `note` TEXT)",
+			want: []Column{
+				{Name: "id", DeclaredType: "INTEGER", Affinity: AffinityInteger},
+				{Name: "name", DeclaredType: "TEXT", Affinity: AffinityText},
+				{Name: "note", DeclaredType: "TEXT", Affinity: AffinityText},
+			},
+		},
+		{
+			name: "check constraint with nested commas is not split",
+			sql:  `CREATE TABLE t (id INTEGER, CHECK (id > 0 AND id < 100), val TEXT)`,
+			want: []Column{
+				{Name: "id", DeclaredType: "INTEGER", Affinity: AffinityInteger},
+				{Name: "val", DeclaredType: "TEXT", Affinity: AffinityText},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.sql)
+			if err != nil {
+				t.Fatalf("Parse(%q) returned error: %v", tt.sql, err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Parse(%q) = %+v, want %+v", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNoColumnList(t *testing.T) {
+	if _, err := Parse("not a create table statement"); err == nil {
+		t.Fatal("expected an error for SQL with no column list")
+	}
+}
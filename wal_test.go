@@ -0,0 +1,59 @@
+package gosqlite
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestOpenDatabaseClosesAutoDiscoveredWAL checks that the "<path>-wal" file
+// OpenDatabase opens on its own (as opposed to one supplied via
+// OpenOptions.WAL) is retained and actually released by Close, rather than
+// being opened and then discarded with no way to ever close it.
+func TestOpenDatabaseClosesAutoDiscoveredWAL(t *testing.T) {
+	dir := t.TempDir()
+
+	src, err := os.ReadFile("testdata/index.db")
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+
+	dbPath := filepath.Join(dir, "test.db")
+	if err := os.WriteFile(dbPath, src, 0o644); err != nil {
+		t.Fatalf("writing db copy: %v", err)
+	}
+
+	// A minimal, frame-less WAL header is enough for openWAL to accept the
+	// file; this test only cares that OpenDatabase retains a closeable
+	// handle to it, not its contents.
+	hdr := make([]byte, walHeaderSize)
+	endian.PutUint32(hdr[0:4], walMagicBE)
+	endian.PutUint32(hdr[8:12], 4096)
+	if err := os.WriteFile(dbPath+"-wal", hdr, 0o644); err != nil {
+		t.Fatalf("writing wal file: %v", err)
+	}
+
+	f, err := os.Open(dbPath)
+	if err != nil {
+		t.Fatalf("opening db copy: %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+
+	db, err := OpenDatabase(f)
+	if err != nil {
+		t.Fatalf("OpenDatabase: %v", err)
+	}
+
+	walFile, ok := db.walFile.(*os.File)
+	if !ok || walFile == nil {
+		t.Fatal("OpenDatabase did not retain a closeable handle to the auto-discovered WAL file")
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if err := walFile.Close(); err == nil {
+		t.Fatal("wal file was still open after db.Close(); expected it to already be closed")
+	}
+}